@@ -2,7 +2,10 @@ package edit
 
 import (
 	"container/list"
+	"context"
 	"strings"
+	"sync"
+	"time"
 	"unicode/utf8"
 )
 
@@ -14,6 +17,36 @@ type listing struct {
 	selected int
 	filter   string
 	pagesize int
+
+	preview       PreviewConfig
+	previewOffset int
+	previewHeight int
+
+	matcher int
+
+	marked    map[int]bool
+	markGlyph string
+
+	jump                 jumpState
+	jumpAlphabetOverride string
+	lastLow, lastHigh    int
+
+	redraw func()
+
+	// asyncMu guards debounce and asyncCancel, the only listing fields a
+	// background filter's debounce timer touches; everything else async
+	// filtering affects (filtering, spinner, selected) is only ever
+	// mutated from the main loop, via changeFilterAsync and
+	// DrainAsyncResults.
+	asyncMu     sync.Mutex
+	debounce    *time.Timer
+	asyncCancel context.CancelFunc
+	asyncEvents chan asyncEvent
+	filtering   bool
+	spinner     int
+
+	layout   LayoutSpec
+	position ListingPosition
 }
 
 type listingProvider interface {
@@ -29,7 +62,7 @@ type Placeholderer interface {
 }
 
 func newListing(t ModeType, p listingProvider) listing {
-	l := listing{t, p, 0, "", 0}
+	l := listing{typ: t, provider: p, preview: defaultPreviewConfig, markGlyph: defaultMarkGlyph, layout: defaultLayout}
 	l.changeFilter("")
 	return l
 }
@@ -44,12 +77,87 @@ func (l *listing) ModeLine(width int) *buffer {
 	b := newBuffer(width)
 	b.writes(TrimWcWidth(title, width), styleForMode)
 	b.writes(" ", "")
+	b.writes("["+l.currentMatcher().Name()+"]", styleForMode)
+	b.writes(" ", "")
+	if spinner := l.spinnerGlyph(); spinner != "" {
+		b.writes(spinner, styleForMode)
+		b.writes(" ", "")
+	}
 	b.writes(l.filter, styleForFilter)
 	b.dot = b.cursor()
 	return b
 }
 
+// currentMatcher returns the Matcher currently selected from the matchers
+// slice via the cycle-matcher builtin, whose name the mode line shows.
+// provider.Filter is not given it, so the selection does not change which
+// entries match.
+func (l *listing) currentMatcher() Matcher {
+	return matchers[l.matcher]
+}
+
+// cycleMatcher selects the next available Matcher and re-runs the provider's
+// Filter with the current filter string.
+func (l *listing) cycleMatcher() {
+	l.matcher = (l.matcher + 1) % len(matchers)
+	l.changeFilter(l.filter)
+}
+
 func (l *listing) List(width, maxHeight int) *buffer {
+	maxHeight = l.clampHeight(maxHeight)
+	width, maxHeight, top, right, bottom, left, bordered := l.layoutInset(width, maxHeight)
+
+	listWidth, listHeight := width, maxHeight
+	showPreview := false
+	var previewW, previewH int
+	if _, ok := l.provider.(PreviewProvider); ok && !l.preview.Hidden {
+		switch l.preview.Position {
+		case PreviewPositionLeft, PreviewPositionRight:
+			previewW = l.preview.Size.resolve(width)
+			previewH = maxHeight
+			listWidth = width - previewW - 1
+		default:
+			previewW = width
+			previewH = l.preview.Size.resolve(maxHeight)
+			listHeight = maxHeight - previewH
+		}
+		showPreview = listWidth > 0 && listHeight > 0
+	}
+	if !showPreview {
+		listWidth, listHeight = width, maxHeight
+	}
+
+	b, height := l.listEntries(listWidth, listHeight)
+
+	if showPreview {
+		pb := renderPreview(l.provider, l.selected, previewW, previewH, l.previewOffset, l.preview)
+		l.previewHeight = previewH
+		if pb != nil {
+			switch l.preview.Position {
+			case PreviewPositionLeft:
+				pb.extendHorizontal(b, previewW+1)
+				b = pb
+				height = previewH
+			case PreviewPositionRight:
+				b.extendHorizontal(pb, listWidth+1)
+				height = previewH
+			case PreviewPositionUp:
+				pb.extend(b, false)
+				b = pb
+				height += previewH
+			default:
+				b.extend(pb, false)
+				height += previewH
+			}
+		}
+	}
+
+	return applyLayout(b, width, height, top, right, bottom, left, l.layout, bordered)
+}
+
+// listEntries renders the entry list portion of a listing mode, without any
+// preview pane.
+func (l *listing) listEntries(width, maxHeight int) (*buffer, int) {
 	n := l.provider.Len()
 	b := newBuffer(width)
 	if n == 0 {
@@ -60,9 +168,12 @@ func (l *listing) List(width, maxHeight int) *buffer {
 			ph = "(no result)"
 		}
 		b.writes(TrimWcWidth(ph, width), "")
-		return b
+		return b, 1
 	}
 
+	markerWidth := utf8.RuneCountInString(l.markGlyph) + 1
+	width -= markerWidth
+
 	// Collect the entries to show. We start from the selected entry and extend
 	// in both directions alternatingly. The entries are collected in a list.
 	low := l.selected
@@ -109,6 +220,7 @@ func (l *listing) List(width, maxHeight int) *buffer {
 	}
 
 	l.pagesize = high - low
+	l.lastLow, l.lastHigh = low, high
 
 	var scrollbar *buffer
 	if low > 0 || high < n-1 {
@@ -122,16 +234,30 @@ func (l *listing) List(width, maxHeight int) *buffer {
 			b.newline()
 		}
 		s := p.Value.(styled)
-		if i == l.selected {
+		marked := l.marked[i]
+		if i == l.selected && marked {
+			s.style += styleForSelected + styleForMarked
+		} else if i == l.selected {
 			s.style += styleForSelected
+		} else if marked {
+			s.style += styleForMarked
+		}
+		if l.jump.enabled {
+			b.writes(l.jumpLabelColumn(i, markerWidth), styleForJumpLabel)
+		} else {
+			b.writes(l.markerColumn(marked), styleForMarked)
+		}
+		if hp, ok := l.provider.(HighlightProvider); ok && !strings.Contains(s.text, "\n") {
+			writeHighlighted(b, s.text, s.style, hp.Highlights(i))
+		} else {
+			b.writes(s.text, s.style)
 		}
-		b.writes(s.text, s.style)
 		p = p.Next()
 	}
 	if scrollbar != nil {
 		b.extendHorizontal(scrollbar, width)
 	}
-	return b
+	return b, height
 }
 
 func renderScrollbar(n, low, high, height int) *buffer {
@@ -167,8 +293,15 @@ func findScrollInterval(n, low, high, height int) (int, int) {
 }
 
 func (l *listing) changeFilter(newfilter string) {
+	if ap, ok := l.provider.(AsyncListingProvider); ok {
+		l.changeFilterAsync(ap, newfilter)
+		return
+	}
 	l.filter = newfilter
 	l.selected = l.provider.Filter(newfilter)
+	l.previewOffset = 0
+	l.resetJump()
+	l.clearSelection()
 }
 
 func (l *listing) backspace() bool {
@@ -193,6 +326,8 @@ func (l *listing) up(cycle bool) {
 			l.selected++
 		}
 	}
+	l.previewOffset = 0
+	l.resetJump()
 }
 
 func (l *listing) pageUp() {
@@ -204,6 +339,8 @@ func (l *listing) pageUp() {
 	if l.selected < 0 {
 		l.selected = 0
 	}
+	l.previewOffset = 0
+	l.resetJump()
 }
 
 func (l *listing) down(cycle bool) {
@@ -219,6 +356,8 @@ func (l *listing) down(cycle bool) {
 			l.selected--
 		}
 	}
+	l.previewOffset = 0
+	l.resetJump()
 }
 
 func (l *listing) pageDown() {
@@ -230,6 +369,8 @@ func (l *listing) pageDown() {
 	if l.selected >= n {
 		l.selected = n - 1
 	}
+	l.previewOffset = 0
+	l.resetJump()
 }
 
 func (l *listing) accept(ed *Editor) {
@@ -238,6 +379,26 @@ func (l *listing) accept(ed *Editor) {
 	}
 }
 
+// previewScroll scrolls the preview pane by delta lines, independently of
+// the current selection.
+func (l *listing) previewScroll(delta int) {
+	l.previewOffset += delta
+	if l.previewOffset < 0 {
+		l.previewOffset = 0
+	}
+}
+
+// previewToggle shows or hides the preview pane.
+func (l *listing) previewToggle() {
+	l.preview.Hidden = !l.preview.Hidden
+}
+
+// SetPreviewConfig overrides the listing mode's preview position, size,
+// wrap and border configuration.
+func (l *listing) SetPreviewConfig(cfg PreviewConfig) {
+	l.preview = cfg
+}
+
 func (l *listing) handleFilterKey(k Key) bool {
 	if likeChar(k) {
 		l.changeFilter(l.filter + string(k.Rune))
@@ -247,6 +408,9 @@ func (l *listing) handleFilterKey(k Key) bool {
 }
 
 func (l *listing) defaultBinding(ed *Editor) {
+	if l.handleJumpKey(ed.lastKey, ed) {
+		return
+	}
 	if !l.handleFilterKey(ed.lastKey) {
 		startInsert(ed)
 		ed.nextAction = action{typ: reprocessKey}
@@ -266,6 +430,10 @@ func addListingBuiltins(prefix string, l func(*Editor) *listing) {
 	add("backspace", func(ed *Editor) { l(ed).backspace() })
 	add("accept", func(ed *Editor) { l(ed).accept(ed) })
 	add("default", func(ed *Editor) { l(ed).defaultBinding(ed) })
+	add("cycle-matcher", func(ed *Editor) { l(ed).cycleMatcher() })
+	addPreviewBuiltins(prefix, l)
+	addMultiBuiltins(prefix, l)
+	addJumpBuiltins(prefix, l)
 }
 
 func addListingDefaultBindings(prefix string, m ModeType) {
@@ -278,9 +446,13 @@ func addListingDefaultBindings(prefix string, m ModeType) {
 	add(Key{PageUp, 0}, "page-up")
 	add(Key{Down, 0}, "down")
 	add(Key{PageDown, 0}, "page-down")
-	add(Key{Tab, 0}, "down-cycle")
+	add(Key{Tab, 0}, "toggle-down")
+	add(Key{Tab, Shift}, "toggle-up")
 	add(Key{Backspace, 0}, "backspace")
-	add(Key{Enter, 0}, "accept")
+	add(Key{Enter, 0}, "accept-multi")
 	add(Default, "default")
 	defaultBindings[m][Key{'[', Ctrl}] = "start-insert"
+	defaultBindings[m][Key{'A', Ctrl}] = prefix + "select-all"
+	defaultBindings[m][Key{'D', Ctrl}] = prefix + "clear-selection"
+	defaultBindings[m][Key{'J', Ctrl}] = prefix + "jump"
 }
@@ -0,0 +1,155 @@
+package edit
+
+import (
+	"context"
+	"time"
+)
+
+// AsyncListingProvider is an optional interface a listingProvider can
+// implement when filtering is too expensive to run synchronously on every
+// keystroke, e.g. because it scans a large history or file tree. When a
+// provider implements it, listing.changeFilter prefers it over the
+// synchronous Filter method.
+type AsyncListingProvider interface {
+	// AsyncFilter filters entries matching filter and streams results to
+	// out as they become available. It must return promptly once ctx is
+	// cancelled, which happens as soon as a newer filter supersedes it.
+	// AsyncFilter must not close out; the listing closes it once
+	// AsyncFilter returns.
+	AsyncFilter(ctx context.Context, filter string, out chan<- []MatchResult)
+}
+
+// filterDebounce is how long changeFilterAsync waits for typing to settle
+// before actually launching a filter, so that fast typing coalesces into a
+// single query.
+const filterDebounce = 20 * time.Millisecond
+
+// spinnerFrames are cycled through while an asynchronous filter is running.
+var spinnerFrames = []rune("_|/-\\")
+
+// asyncEvent is what a background AsyncFilter goroutine posts to the
+// listing's asyncEvents channel. Nothing but sending on that channel (and
+// calling notifyRedraw) happens from the worker goroutine; applying an
+// event to the listing's render state happens on the main loop, in
+// DrainAsyncResults.
+type asyncEvent struct {
+	results []MatchResult
+	done    bool
+}
+
+// SetRedrawNotifier registers the callback the listing mode invokes, from
+// the worker's goroutine, after an asynchronous filter delivers new results.
+// The results reach the listing's render state only when DrainAsyncResults
+// is called.
+func (l *listing) SetRedrawNotifier(f func()) {
+	l.redraw = f
+}
+
+// changeFilterAsync is changeFilter's counterpart for providers that
+// implement AsyncListingProvider. It cancels any in-flight filter,
+// debounces, then streams partial results back to the main loop as they
+// arrive.
+func (l *listing) changeFilterAsync(ap AsyncListingProvider, newfilter string) {
+	l.filter = newfilter
+	l.previewOffset = 0
+	l.resetJump()
+	l.clearSelection()
+	l.filtering = true
+
+	if l.asyncEvents == nil {
+		l.asyncEvents = make(chan asyncEvent, 16)
+	}
+
+	l.asyncMu.Lock()
+	if l.debounce != nil {
+		l.debounce.Stop()
+	}
+	if l.asyncCancel != nil {
+		l.asyncCancel()
+	}
+	l.debounce = time.AfterFunc(filterDebounce, func() {
+		l.launchAsyncFilter(ap, newfilter)
+	})
+	l.asyncMu.Unlock()
+}
+
+// launchAsyncFilter starts the worker goroutine. It runs on the debounce
+// timer's own goroutine, so it may only touch asyncMu-guarded bookkeeping
+// fields (debounce, asyncCancel) — never the render state (selected,
+// filtering, spinner) that List/ModeLine read on the main loop.
+func (l *listing) launchAsyncFilter(ap AsyncListingProvider, filter string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.asyncMu.Lock()
+	// A superseded timer can launch after changeFilterAsync already
+	// cancelled; cancel whatever this launch replaces.
+	if l.asyncCancel != nil {
+		l.asyncCancel()
+	}
+	l.asyncCancel = cancel
+	l.asyncMu.Unlock()
+
+	out := make(chan []MatchResult)
+	go func() {
+		ap.AsyncFilter(ctx, filter, out)
+		close(out)
+	}()
+	go func() {
+		for results := range out {
+			l.asyncEvents <- asyncEvent{results: results}
+			l.notifyRedraw()
+		}
+		l.asyncEvents <- asyncEvent{done: true}
+		l.notifyRedraw()
+	}()
+}
+
+// DrainAsyncResults applies any asynchronous filter results pending on
+// asyncEvents to the listing's render state. It must be called from the
+// editor's main loop, typically right after the redraw notifier fires.
+func (l *listing) DrainAsyncResults() {
+	for {
+		select {
+		case ev := <-l.asyncEvents:
+			if ev.done {
+				l.filtering = false
+				continue
+			}
+			l.filtering = true
+			l.applyAsyncResults(ev.results)
+		default:
+			return
+		}
+	}
+}
+
+// applyAsyncResults updates the listing's selection from a partial or final
+// batch of async results. The concrete listingProvider is expected to have
+// updated its own backing data before sending on the channel; this only
+// keeps the selection in range. Only ever called from DrainAsyncResults, on
+// the main loop.
+func (l *listing) applyAsyncResults(results []MatchResult) {
+	l.spinner = (l.spinner + 1) % len(spinnerFrames)
+	n := l.provider.Len()
+	if n == 0 {
+		l.selected = -1
+	} else if l.selected >= n {
+		l.selected = n - 1
+	} else if l.selected < 0 {
+		l.selected = 0
+	}
+}
+
+func (l *listing) notifyRedraw() {
+	if l.redraw != nil {
+		l.redraw()
+	}
+}
+
+// spinnerGlyph returns the current spinner frame while a filter is in
+// flight, or the empty string otherwise.
+func (l *listing) spinnerGlyph() string {
+	if !l.filtering {
+		return ""
+	}
+	return string(spinnerFrames[l.spinner])
+}
@@ -0,0 +1,137 @@
+package edit
+
+import "strings"
+
+// defaultJumpAlphabet is the default set of characters jump labels are
+// drawn from, ordered by home-row proximity like vim-easymotion's default.
+const defaultJumpAlphabet = "asdfghjkl;"
+
+// jumpState tracks an in-progress jump-to-entry selection, entered via the
+// jump/jump-accept builtins.
+type jumpState struct {
+	enabled bool
+	// labels maps a full label to the entry index it selects.
+	labels map[string]int
+	// byIndex maps an entry index to its label, for rendering.
+	byIndex map[int]string
+	// typed is the label prefix entered so far.
+	typed string
+	// accept, when true, immediately accepts the entry on a full label
+	// match instead of merely selecting it.
+	accept bool
+}
+
+// jumpLabelsFor computes the label for each entry index in [low, high),
+// matching the window List already tracks. One-character labels are used
+// while the alphabet covers the range; otherwise two-character labels are
+// generated by pairing every letter with every letter, itself included.
+// Entries beyond the first len(alphabet)² get no label.
+func jumpLabelsFor(alphabet string, low, high int) map[int]string {
+	letters := strings.Split(alphabet, "")
+	n := high - low
+	labels := make(map[int]string, n)
+	if n <= len(letters) {
+		for i := 0; i < n; i++ {
+			labels[low+i] = letters[i]
+		}
+		return labels
+	}
+	i := 0
+	for _, a := range letters {
+		for _, b := range letters {
+			if i >= n {
+				return labels
+			}
+			labels[low+i] = a + b
+			i++
+		}
+	}
+	return labels
+}
+
+// enterJump enters jump mode for the entries currently visible in [low,
+// high), as last rendered by List.
+func (l *listing) enterJump(accept bool) {
+	if l.lastLow == l.lastHigh {
+		return
+	}
+	byIndex := jumpLabelsFor(l.jumpAlphabet(), l.lastLow, l.lastHigh)
+	byLabel := make(map[string]int, len(byIndex))
+	for i, label := range byIndex {
+		byLabel[label] = i
+	}
+	l.jump = jumpState{enabled: true, labels: byLabel, byIndex: byIndex, accept: accept}
+}
+
+// jumpAlphabet returns the configured jump alphabet, falling back to the
+// default when unset.
+func (l *listing) jumpAlphabet() string {
+	if l.jumpAlphabetOverride != "" {
+		return l.jumpAlphabetOverride
+	}
+	return defaultJumpAlphabet
+}
+
+// handleJumpKey consumes a key while in jump mode, narrowing the candidate
+// labels or selecting an entry on a full match. It returns whether the key
+// was consumed.
+func (l *listing) handleJumpKey(k Key, ed *Editor) bool {
+	if !l.jump.enabled || !likeChar(k) {
+		return false
+	}
+	typed := l.jump.typed + string(k.Rune)
+	if i, ok := l.jump.labels[typed]; ok {
+		l.selected = i
+		l.previewOffset = 0
+		if ed != nil {
+			l.afterJump(ed)
+		}
+		l.jump = jumpState{}
+		return true
+	}
+	anyPrefix := false
+	for label := range l.jump.labels {
+		if strings.HasPrefix(label, typed) {
+			anyPrefix = true
+			break
+		}
+	}
+	if !anyPrefix {
+		l.jump = jumpState{}
+		return true
+	}
+	l.jump.typed = typed
+	return true
+}
+
+func (l *listing) afterJump(ed *Editor) {
+	if l.jump.accept {
+		l.accept(ed)
+	}
+}
+
+// resetJump cancels any in-progress jump. It is called whenever the filter,
+// selection or scroll position changes, since the labels are only valid for
+// the window they were computed from.
+func (l *listing) resetJump() {
+	l.jump = jumpState{}
+}
+
+// jumpLabelColumn renders entry i's jump label padded to width columns, so
+// it occupies the same space as the marker column without shifting the
+// entry text that follows it.
+func (l *listing) jumpLabelColumn(i, width int) string {
+	label := l.jump.byIndex[i]
+	if pad := width - len(label); pad > 0 {
+		label += strings.Repeat(" ", pad)
+	}
+	return label
+}
+
+func addJumpBuiltins(prefix string, l func(*Editor) *listing) {
+	add := func(name string, f func(*Editor)) {
+		builtins = append(builtins, Builtin{prefix + name, f})
+	}
+	add("jump", func(ed *Editor) { l(ed).enterJump(false) })
+	add("jump-accept", func(ed *Editor) { l(ed).enterJump(true) })
+}
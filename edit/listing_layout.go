@@ -0,0 +1,176 @@
+package edit
+
+import "strings"
+
+// SizeSpec specifies a length, either as an absolute number of rows or
+// columns, or as a percentage of the space available. Absolute takes
+// precedence when both are non-zero.
+type SizeSpec struct {
+	Absolute int
+	Percent  int
+}
+
+func (s SizeSpec) resolve(total int) int {
+	switch {
+	case s.Absolute > 0:
+		return s.Absolute
+	case s.Percent > 0:
+		return total * s.Percent / 100
+	default:
+		return 0
+	}
+}
+
+// ListingPosition controls where the listing window anchors relative to the
+// prompt.
+type ListingPosition int
+
+// Possible values of ListingPosition.
+const (
+	ListingPositionDown ListingPosition = iota
+	ListingPositionUp
+	ListingPositionCenter
+)
+
+// LayoutSpec configures the margins, border and maximum height of a listing
+// mode's window, borrowing the shape of fzf's --margin and
+// --preview-window border options.
+type LayoutSpec struct {
+	// Margin is [top, right, bottom, left], as with CSS.
+	Margin    [4]SizeSpec
+	Border    BorderStyle
+	MaxHeight SizeSpec
+}
+
+var defaultLayout = LayoutSpec{}
+
+// SetLayout overrides the listing mode's margin, border and max-height
+// configuration.
+func (l *listing) SetLayout(spec LayoutSpec) {
+	l.layout = spec
+}
+
+// SetPosition records where the listing window anchors relative to the
+// prompt, for Position to report back. Nothing in the listing mode reads it:
+// List renders the same buffer whatever the position.
+func (l *listing) SetPosition(p ListingPosition) {
+	l.position = p
+}
+
+// Position reports the listing window's configured anchor.
+func (l *listing) Position() ListingPosition {
+	return l.position
+}
+
+// clampHeight applies the layout's configured max height on top of the
+// height the editor otherwise allocated.
+func (l *listing) clampHeight(maxHeight int) int {
+	if h := l.layout.MaxHeight.resolve(maxHeight); h > 0 && h < maxHeight {
+		return h
+	}
+	return maxHeight
+}
+
+// layoutInset computes how many rows/columns the configured margin and
+// border consume out of a width x maxHeight budget, and the content area
+// left over for the entry list (and preview) to render into. The content
+// area is always inset from, never added on top of, the given budget.
+func (l *listing) layoutInset(width, maxHeight int) (contentWidth, contentHeight, top, right, bottom, left int, bordered bool) {
+	spec := l.layout
+	top = spec.Margin[0].resolve(maxHeight)
+	right = spec.Margin[1].resolve(width)
+	bottom = spec.Margin[2].resolve(maxHeight)
+	left = spec.Margin[3].resolve(width)
+	_, bordered = spec.Border.glyphs()
+
+	borderRows, borderCols := 0, 0
+	if bordered {
+		borderRows, borderCols = 2, 2
+	}
+	contentWidth = width - left - right - borderCols
+	contentHeight = maxHeight - top - bottom - borderRows
+	if contentWidth < 1 {
+		contentWidth = 1
+	}
+	if contentHeight < 1 {
+		contentHeight = 1
+	}
+	return
+}
+
+// applyLayout wraps the already-rendered content buffer b (of dimensions
+// contentWidth x contentHeight) in the border and margin computed by
+// layoutInset.
+func applyLayout(b *buffer, contentWidth, contentHeight, top, right, bottom, left int, spec LayoutSpec, bordered bool) *buffer {
+	width, height := contentWidth, contentHeight
+	if bordered {
+		glyphs, _ := spec.Border.glyphs()
+		b = addBorder(b, width, height, glyphs)
+		width += 2
+		height += 2
+	}
+
+	if left > 0 {
+		b = withHorizontalMargin(b, left, width, height, true)
+		width += left
+	}
+	if right > 0 {
+		b = withHorizontalMargin(b, right, width, height, false)
+		width += right
+	}
+	if top > 0 {
+		blank := newBuffer(width)
+		for i := 1; i < top; i++ {
+			blank.newline()
+		}
+		blank.extend(b, false)
+		b = blank
+	}
+	if bottom > 0 {
+		blank := newBuffer(width)
+		for i := 1; i < bottom; i++ {
+			blank.newline()
+		}
+		b.extend(blank, false)
+	}
+	return b
+}
+
+func addBorder(b *buffer, width, height int, g borderGlyphs) *buffer {
+	top := newBuffer(width + 2)
+	top.writes(string(g.tl)+strings.Repeat(string(g.t), width)+string(g.tr), styleForMode)
+
+	left := newBuffer(1)
+	right := newBuffer(1)
+	for i := 0; i < height; i++ {
+		if i > 0 {
+			left.newline()
+			right.newline()
+		}
+		left.writes(string(g.l), styleForMode)
+		right.writes(string(g.r), styleForMode)
+	}
+
+	bottom := newBuffer(width + 2)
+	bottom.writes(string(g.bl)+strings.Repeat(string(g.b), width)+string(g.br), styleForMode)
+
+	left.extendHorizontal(b, 1)
+	left.extendHorizontal(right, 1+width)
+
+	top.extend(left, false)
+	top.extend(bottom, false)
+	return top
+}
+
+func withHorizontalMargin(b *buffer, n, existingWidth, height int, before bool) *buffer {
+	margin := newBuffer(n)
+	for i := 1; i < height; i++ {
+		margin.newline()
+	}
+	if before {
+		margin.extendHorizontal(b, n)
+		return margin
+	}
+	b.extendHorizontal(margin, existingWidth)
+	return b
+}
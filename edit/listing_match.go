@@ -0,0 +1,346 @@
+package edit
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// MatchItem is a single candidate passed to FilterEntries: Index identifies
+// the entry for the provider (typically its index in the backing slice) and
+// Text is what gets matched against the query.
+type MatchItem struct {
+	Index int
+	Text  string
+}
+
+// MatchResult is the outcome of matching one MatchItem against a query. Hows
+// are pairs of [start, end) byte offsets into Text that matched, used by
+// List to highlight the match.
+type MatchResult struct {
+	Index int
+	Score int
+	Hows  [][2]int
+}
+
+// Matcher ranks a list of candidate strings against a query, returning the
+// ones that match together with a score (higher is better) and the byte
+// ranges that matched, for highlighting.
+type Matcher interface {
+	// Name is the matcher's short, user-facing name, shown in the mode line.
+	Name() string
+	// Match reports whether text matches query, and if so, its score and
+	// the byte ranges within text that matched.
+	Match(text, query string) (ok bool, score int, hows [][2]int)
+}
+
+// FilterEntries matches every item against query using m, and returns the
+// matches sorted by descending score (ties keep the original order).
+func FilterEntries(items []MatchItem, query string, m Matcher) []MatchResult {
+	if query == "" {
+		results := make([]MatchResult, len(items))
+		for i, it := range items {
+			results[i] = MatchResult{Index: it.Index}
+		}
+		return results
+	}
+	var results []MatchResult
+	for _, it := range items {
+		ok, score, hows := m.Match(it.Text, query)
+		if ok {
+			results = append(results, MatchResult{Index: it.Index, Score: score, Hows: hows})
+		}
+	}
+	// Stable sort so that ties preserve the original relative order.
+	for i := 1; i < len(results); i++ {
+		for j := i; j > 0 && results[j].Score > results[j-1].Score; j-- {
+			results[j], results[j-1] = results[j-1], results[j]
+		}
+	}
+	return results
+}
+
+// substringMatcher matches text containing query as a substring. With
+// smartCase, the match ignores case unless query has an uppercase letter.
+type substringMatcher struct{ smartCase bool }
+
+func (m substringMatcher) Name() string {
+	if m.smartCase {
+		return "SUBSTR(smart)"
+	}
+	return "SUBSTR"
+}
+
+func (m substringMatcher) Match(text, query string) (bool, int, [][2]int) {
+	haystack, needle := text, query
+	if m.smartCase && !hasUpper(query) {
+		haystack = strings.ToLower(text)
+		needle = strings.ToLower(query)
+	}
+	i := strings.Index(haystack, needle)
+	if i == -1 {
+		return false, 0, nil
+	}
+	return true, len(needle), [][2]int{{i, i + len(needle)}}
+}
+
+func hasUpper(s string) bool {
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzyMatcher implements fzf-style fuzzy matching. V2 uses a
+// Smith-Waterman-like scoring pass with bonuses for word boundaries,
+// camelCase transitions and path separators; V1 is the simpler greedy
+// algorithm fzf uses as a faster fallback.
+type fuzzyMatcher struct{ v2 bool }
+
+func (m fuzzyMatcher) Name() string {
+	if m.v2 {
+		return "FUZZY"
+	}
+	return "FUZZY(v1)"
+}
+
+const (
+	bonusBoundary    = 8
+	bonusCamel       = 7
+	bonusPathSep     = 9
+	bonusConsecutive = 5
+)
+
+func isBoundary(prev, cur rune) bool {
+	if prev == 0 {
+		return true
+	}
+	if prev == '/' || prev == '_' || prev == '-' || prev == '.' || prev == ' ' {
+		return true
+	}
+	if unicode.IsLower(prev) && unicode.IsUpper(cur) {
+		return true
+	}
+	return false
+}
+
+func (m fuzzyMatcher) Match(text, query string) (bool, int, [][2]int) {
+	if query == "" {
+		return true, 0, nil
+	}
+	lowerText := []rune(strings.ToLower(text))
+	lowerQuery := []rune(strings.ToLower(query))
+	runes := []rune(text)
+
+	var ok bool
+	var score int
+	var hows [][2]int
+	if m.v2 {
+		ok, score, hows = fuzzyMatchV2(runes, lowerText, lowerQuery)
+	} else {
+		ok, score, hows = fuzzyMatchV1(runes, lowerText, lowerQuery)
+	}
+	// The match functions work on rune indices; convert to byte offsets.
+	offsets := make([]int, 0, len(runes)+1)
+	for i := range text {
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(text))
+	for k, how := range hows {
+		hows[k] = [2]int{offsets[how[0]], offsets[how[1]]}
+	}
+	return ok, score, hows
+}
+
+// fuzzyMatchV1 greedily matches each query rune against the first remaining
+// occurrence in text.
+func fuzzyMatchV1(runes, lowerText, lowerQuery []rune) (bool, int, [][2]int) {
+	var hows [][2]int
+	score, ti := 0, 0
+	for _, qr := range lowerQuery {
+		found := -1
+		for ; ti < len(lowerText); ti++ {
+			if lowerText[ti] == qr {
+				found = ti
+				break
+			}
+		}
+		if found == -1 {
+			return false, 0, nil
+		}
+		hows = append(hows, [2]int{found, found + 1})
+		if found > 0 && isBoundary(lowerText[found-1], runes[found]) {
+			score += bonusBoundary
+		}
+		score++
+		ti = found + 1
+	}
+	return true, score, mergeRanges(hows)
+}
+
+// fuzzyMatchV2 scores matches with a Smith-Waterman-like dynamic program,
+// preferring consecutive runs and matches right after word boundaries.
+func fuzzyMatchV2(runes, lowerText, lowerQuery []rune) (bool, int, [][2]int) {
+	n, qn := len(lowerText), len(lowerQuery)
+	if qn == 0 {
+		return true, 0, nil
+	}
+	// dp[i][j]: best score matching query[:j] ending with query[j-1] matched
+	// at text position i.
+	const negInf = -1 << 30
+	dp := make([][]int, n+1)
+	from := make([][]int, n+1)
+	for i := range dp {
+		dp[i] = make([]int, qn+1)
+		from[i] = make([]int, qn+1)
+		for j := range dp[i] {
+			dp[i][j] = negInf
+			from[i][j] = -1
+		}
+	}
+	for i := 0; i <= n; i++ {
+		dp[i][0] = 0
+	}
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= qn && j <= i; j++ {
+			if lowerText[i-1] != lowerQuery[j-1] {
+				continue
+			}
+			bonus := 1
+			if runes[i-1] == '/' {
+				bonus += bonusPathSep
+			} else if i > 1 && isBoundary(runeAt(lowerText, i-2), runes[i-1]) {
+				bonus += bonusBoundary
+			} else if i > 1 && unicode.IsLower(runes[i-2]) && unicode.IsUpper(runes[i-1]) {
+				bonus += bonusCamel
+			}
+			if from[i-1][j-1] == i-2 {
+				bonus += bonusConsecutive
+			}
+			if dp[i-1][j-1] != negInf && dp[i-1][j-1]+bonus > dp[i][j] {
+				dp[i][j] = dp[i-1][j-1] + bonus
+				from[i][j] = i - 1
+			}
+		}
+		for j := 1; j <= qn; j++ {
+			if dp[i-1][j] > dp[i][j] {
+				dp[i][j] = dp[i-1][j]
+				from[i][j] = from[i-1][j]
+			}
+		}
+	}
+	if dp[n][qn] == negInf {
+		return false, 0, nil
+	}
+	// Reconstruct match positions by walking back through the last row that
+	// actually advanced j.
+	var hows [][2]int
+	i, j := n, qn
+	for j > 0 {
+		for i > 0 && (dp[i][j] == dp[i-1][j]) {
+			i--
+		}
+		hows = append([][2]int{{i - 1, i}}, hows...)
+		i--
+		j--
+	}
+	return true, dp[n][qn], mergeRanges(hows)
+}
+
+func runeAt(rs []rune, i int) rune {
+	if i < 0 || i >= len(rs) {
+		return 0
+	}
+	return rs[i]
+}
+
+// mergeRanges merges adjacent or overlapping [start, end) ranges, assuming
+// ranges is sorted by start.
+func mergeRanges(ranges [][2]int) [][2]int {
+	if len(ranges) == 0 {
+		return nil
+	}
+	merged := [][2]int{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r[0] <= last[1] {
+			if r[1] > last[1] {
+				last[1] = r[1]
+			}
+		} else {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
+
+// regexMatcher matches text against query compiled as an RE2 regular
+// expression.
+type regexMatcher struct{}
+
+func (m regexMatcher) Name() string { return "REGEX" }
+
+func (m regexMatcher) Match(text, query string) (bool, int, [][2]int) {
+	re, err := regexp.Compile(query)
+	if err != nil {
+		return false, 0, nil
+	}
+	loc := re.FindStringIndex(text)
+	if loc == nil {
+		return false, 0, nil
+	}
+	return true, loc[1] - loc[0], [][2]int{{loc[0], loc[1]}}
+}
+
+// HighlightProvider is an optional interface a listingProvider can
+// implement to expose the byte ranges within entry i's displayed text that
+// matched the current filter, typically computed via FilterEntries and
+// stashed per-entry when Filter runs. listEntries renders these ranges with
+// styleForMatch so users can see what matched.
+type HighlightProvider interface {
+	Highlights(i int) [][2]int
+}
+
+// writeHighlighted writes text to b, rendering the byte ranges in hows with
+// baseStyle+styleForMatch and everything else with baseStyle.
+func writeHighlighted(b *buffer, text, baseStyle string, hows [][2]int) {
+	if len(hows) == 0 {
+		b.writes(text, baseStyle)
+		return
+	}
+	pos := 0
+	for _, how := range hows {
+		start, end := how[0], how[1]
+		if start < pos {
+			start = pos
+		}
+		if start > len(text) {
+			break
+		}
+		if end > len(text) {
+			end = len(text)
+		}
+		if start > pos {
+			b.writes(text[pos:start], baseStyle)
+		}
+		if end > start {
+			b.writes(text[start:end], baseStyle+styleForMatch)
+		}
+		pos = end
+	}
+	if pos < len(text) {
+		b.writes(text[pos:], baseStyle)
+	}
+}
+
+// matchers lists the available Matcher implementations, in the order
+// cycle-matcher cycles through them.
+var matchers = []Matcher{
+	fuzzyMatcher{v2: true},
+	fuzzyMatcher{v2: false},
+	substringMatcher{smartCase: true},
+	substringMatcher{smartCase: false},
+	regexMatcher{},
+}
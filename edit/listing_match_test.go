@@ -0,0 +1,142 @@
+package edit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterEntries(t *testing.T) {
+	items := []MatchItem{
+		{Index: 0, Text: "foo"},
+		{Index: 1, Text: "bar"},
+		{Index: 2, Text: "foobar"},
+	}
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{"empty query returns all in original order", "", []int{0, 1, 2}},
+		{"substring filters out non-matches", "foo", []int{0, 2}},
+		{"ties keep original relative order", "bar", []int{1, 2}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results := FilterEntries(items, tc.query, substringMatcher{})
+			var got []int
+			for _, r := range results {
+				got = append(got, r.Index)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("FilterEntries(%q) indices = %v, want %v", tc.query, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatchV2(t *testing.T) {
+	m := fuzzyMatcher{v2: true}
+
+	t.Run("no match", func(t *testing.T) {
+		if ok, _, _ := m.Match("hello", "xyz"); ok {
+			t.Fatal("expected no match")
+		}
+	})
+
+	t.Run("exact substring matches contiguously", func(t *testing.T) {
+		ok, _, hows := m.Match("hello", "hello")
+		if !ok {
+			t.Fatal("expected a match")
+		}
+		want := [][2]int{{0, 5}}
+		if !reflect.DeepEqual(hows, want) {
+			t.Errorf("hows = %v, want %v", hows, want)
+		}
+	})
+
+	t.Run("subsequence match spells back the query", func(t *testing.T) {
+		text, query := "hello world", "hwd"
+		ok, _, hows := m.Match(text, query)
+		if !ok {
+			t.Fatal("expected a match")
+		}
+		var got string
+		for _, how := range hows {
+			got += text[how[0]:how[1]]
+		}
+		if got != query {
+			t.Errorf("matched substrings spell %q, want %q", got, query)
+		}
+	})
+
+	t.Run("case insensitive", func(t *testing.T) {
+		if ok, _, _ := m.Match("Hello", "HELLO"); !ok {
+			t.Fatal("expected a case-insensitive match")
+		}
+	})
+
+	t.Run("consecutive run scores higher than a scattered match", func(t *testing.T) {
+		_, consecutive, _ := m.Match("abcdef", "abc")
+		_, scattered, _ := m.Match("aXbXc", "abc")
+		if consecutive <= scattered {
+			t.Errorf("consecutive score %d should be greater than scattered score %d", consecutive, scattered)
+		}
+	})
+}
+
+func TestMergeRanges(t *testing.T) {
+	tests := []struct {
+		name string
+		in   [][2]int
+		want [][2]int
+	}{
+		{"empty", nil, nil},
+		{"single range", [][2]int{{0, 2}}, [][2]int{{0, 2}}},
+		{"adjacent ranges merge", [][2]int{{0, 2}, {2, 4}}, [][2]int{{0, 4}}},
+		{"overlapping ranges merge", [][2]int{{0, 3}, {2, 5}}, [][2]int{{0, 5}}},
+		{"disjoint ranges stay separate", [][2]int{{0, 1}, {3, 4}}, [][2]int{{0, 1}, {3, 4}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := mergeRanges(tc.in)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("mergeRanges(%v) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJumpLabelsFor(t *testing.T) {
+	t.Run("within alphabet uses one-char labels", func(t *testing.T) {
+		labels := jumpLabelsFor("asdf", 0, 3)
+		if len(labels) != 3 {
+			t.Fatalf("got %d labels, want 3", len(labels))
+		}
+		for i := 0; i < 3; i++ {
+			if len(labels[i]) != 1 {
+				t.Errorf("labels[%d] = %q, want a single character", i, labels[i])
+			}
+		}
+	})
+
+	t.Run("beyond alphabet falls back to unique two-char labels", func(t *testing.T) {
+		labels := jumpLabelsFor("ab", 10, 14)
+		if len(labels) != 4 {
+			t.Fatalf("got %d labels, want 4", len(labels))
+		}
+		seen := make(map[string]bool, len(labels))
+		for i := 10; i < 14; i++ {
+			label, ok := labels[i]
+			if !ok {
+				t.Fatalf("missing label for index %d", i)
+			}
+			if len(label) != 2 {
+				t.Errorf("labels[%d] = %q, want two characters", i, label)
+			}
+			if seen[label] {
+				t.Errorf("duplicate label %q", label)
+			}
+			seen[label] = true
+		}
+	})
+}
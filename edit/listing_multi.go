@@ -0,0 +1,111 @@
+package edit
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultMarkGlyph is drawn in the marker column before a selected entry.
+const defaultMarkGlyph = "●"
+
+// markerColumn renders the fixed-width marker column that precedes each
+// entry: the mark glyph followed by a space when marked is true, or blank
+// padding of the same width otherwise.
+func (l *listing) markerColumn(marked bool) string {
+	glyphWidth := utf8.RuneCountInString(l.markGlyph)
+	if !marked {
+		return strings.Repeat(" ", glyphWidth+1)
+	}
+	return l.markGlyph + " "
+}
+
+// MultiAcceptor is an optional interface a listingProvider can implement to
+// handle accepting several selected entries at once, mirroring fzf's
+// --multi. Indices are in ascending order.
+type MultiAcceptor interface {
+	AcceptMulti(indices []int, ed *Editor)
+}
+
+// toggle flips the selection state of the currently highlighted entry.
+func (l *listing) toggle() {
+	if l.selected < 0 {
+		return
+	}
+	if l.marked == nil {
+		l.marked = make(map[int]bool)
+	}
+	if l.marked[l.selected] {
+		delete(l.marked, l.selected)
+	} else {
+		l.marked[l.selected] = true
+	}
+}
+
+// selectAll marks every visible entry as selected.
+func (l *listing) selectAll() {
+	n := l.provider.Len()
+	l.marked = make(map[int]bool, n)
+	for i := 0; i < n; i++ {
+		l.marked[i] = true
+	}
+}
+
+// invertSelection flips the selection state of every visible entry.
+func (l *listing) invertSelection() {
+	n := l.provider.Len()
+	marked := make(map[int]bool, n)
+	for i := 0; i < n; i++ {
+		if !l.marked[i] {
+			marked[i] = true
+		}
+	}
+	l.marked = marked
+}
+
+// clearSelection empties the selection set.
+func (l *listing) clearSelection() {
+	l.marked = nil
+}
+
+// selectedIndices returns the marked indices in ascending order.
+func (l *listing) selectedIndices() []int {
+	indices := make([]int, 0, len(l.marked))
+	for i := range l.marked {
+		indices = append(indices, i)
+	}
+	for i := 1; i < len(indices); i++ {
+		for j := i; j > 0 && indices[j] < indices[j-1]; j-- {
+			indices[j], indices[j-1] = indices[j-1], indices[j]
+		}
+	}
+	return indices
+}
+
+// acceptMulti accepts the current selection in bulk if the provider
+// supports it and the selection is non-empty, falling back to the ordinary
+// single-entry accept otherwise.
+func (l *listing) acceptMulti(ed *Editor) {
+	if len(l.marked) == 0 {
+		l.accept(ed)
+		return
+	}
+	ma, ok := l.provider.(MultiAcceptor)
+	if !ok {
+		l.accept(ed)
+		return
+	}
+	ma.AcceptMulti(l.selectedIndices(), ed)
+}
+
+func addMultiBuiltins(prefix string, l func(*Editor) *listing) {
+	add := func(name string, f func(*Editor)) {
+		builtins = append(builtins, Builtin{prefix + name, f})
+	}
+	add("toggle", func(ed *Editor) { l(ed).toggle() })
+	add("toggle-down", func(ed *Editor) { lst := l(ed); lst.toggle(); lst.down(true) })
+	add("toggle-up", func(ed *Editor) { lst := l(ed); lst.toggle(); lst.up(true) })
+	add("select-all", func(ed *Editor) { l(ed).selectAll() })
+	add("invert-selection", func(ed *Editor) { l(ed).invertSelection() })
+	add("clear-selection", func(ed *Editor) { l(ed).clearSelection() })
+	add("accept-multi", func(ed *Editor) { l(ed).acceptMulti(ed) })
+}
@@ -0,0 +1,185 @@
+package edit
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// PreviewProvider is an optional interface that a listingProvider can
+// implement to supply a preview of its currently selected entry, inspired by
+// fzf's --preview. When the provider does not implement it, listing modes
+// render without a preview pane.
+type PreviewProvider interface {
+	Preview(i, w, h int) styled
+}
+
+// PreviewPosition specifies where the preview pane is drawn relative to the
+// entry list.
+type PreviewPosition int
+
+// Possible values of PreviewPosition.
+const (
+	PreviewPositionDown PreviewPosition = iota
+	PreviewPositionUp
+	PreviewPositionLeft
+	PreviewPositionRight
+)
+
+// PreviewSize specifies the preview pane's size, either as an absolute
+// number of rows or columns, or as a percentage of the space available to
+// the listing mode. Absolute takes precedence when both are non-zero.
+type PreviewSize = SizeSpec
+
+// PreviewConfig holds the user-configurable aspects of a listing mode's
+// preview pane.
+type PreviewConfig struct {
+	Position PreviewPosition
+	Size     PreviewSize
+	Wrap     bool
+	Hidden   bool
+	Border   BorderStyle
+}
+
+var defaultPreviewConfig = PreviewConfig{
+	Position: PreviewPositionRight,
+	Size:     PreviewSize{Percent: 50},
+}
+
+// BorderStyle selects the glyphs used to draw a box around a listing UI
+// element. The zero value, BorderNone, draws no box.
+type BorderStyle int
+
+// Possible values of BorderStyle.
+const (
+	BorderNone BorderStyle = iota
+	BorderRounded
+	BorderSharp
+	BorderDouble
+)
+
+type borderGlyphs struct {
+	tl, t, tr, l, r, bl, b, br rune
+}
+
+func (bs BorderStyle) glyphs() (borderGlyphs, bool) {
+	switch bs {
+	case BorderRounded:
+		return borderGlyphs{'╭', '─', '╮', '│', '│', '╰', '─', '╯'}, true
+	case BorderSharp:
+		return borderGlyphs{'┌', '─', '┐', '│', '│', '└', '─', '┘'}, true
+	case BorderDouble:
+		return borderGlyphs{'╔', '═', '╗', '║', '║', '╚', '═', '╝'}, true
+	default:
+		return borderGlyphs{}, false
+	}
+}
+
+// renderPreview renders the preview of entry i into a w by h buffer,
+// honoring cfg's wrap and border settings. It returns nil when the provider
+// has no preview to show or the area is too small for one.
+func renderPreview(p listingProvider, i, w, h, offset int, cfg PreviewConfig) *buffer {
+	pp, ok := p.(PreviewProvider)
+	if !ok || cfg.Hidden || i < 0 || w <= 0 || h <= 0 {
+		return nil
+	}
+	glyphs, bordered := cfg.Border.glyphs()
+	innerW, innerH := w, h
+	if bordered {
+		innerW -= 2
+		innerH -= 2
+	}
+	if innerW <= 0 || innerH <= 0 {
+		return nil
+	}
+
+	s := pp.Preview(i, innerW, innerH+offset)
+	lines := strings.Split(s.text, "\n")
+	if offset < len(lines) {
+		lines = lines[offset:]
+	} else {
+		lines = nil
+	}
+	if cfg.Wrap {
+		lines = wrapLines(lines, innerW)
+	} else {
+		for j, line := range lines {
+			lines[j] = TrimWcWidth(line, innerW)
+		}
+	}
+	if len(lines) > innerH {
+		lines = lines[:innerH]
+	}
+	for len(lines) < innerH {
+		lines = append(lines, "")
+	}
+
+	b := newBuffer(w)
+	if bordered {
+		b.writes(string(glyphs.tl)+strings.Repeat(string(glyphs.t), w-2)+string(glyphs.tr), styleForMode)
+	}
+	for j, line := range lines {
+		if j > 0 || bordered {
+			b.newline()
+		}
+		if bordered {
+			b.writes(string(glyphs.l), styleForMode)
+		}
+		b.writes(line, s.style)
+		if bordered {
+			if pad := innerW - utf8.RuneCountInString(line); pad > 0 {
+				b.writes(strings.Repeat(" ", pad), "")
+			}
+			b.writes(string(glyphs.r), styleForMode)
+		}
+	}
+	if bordered {
+		b.newline()
+		b.writes(string(glyphs.bl)+strings.Repeat(string(glyphs.b), w-2)+string(glyphs.br), styleForMode)
+	}
+	return b
+}
+
+// wrapLines splits each line into one or more rows of at most w runes, so
+// that a preview with Wrap set never overflows its pane width.
+func wrapLines(lines []string, w int) []string {
+	if w <= 0 {
+		return lines
+	}
+	wrapped := make([]string, 0, len(lines))
+	for _, line := range lines {
+		runes := []rune(line)
+		if len(runes) == 0 {
+			wrapped = append(wrapped, "")
+			continue
+		}
+		for len(runes) > 0 {
+			n := w
+			if n > len(runes) {
+				n = len(runes)
+			}
+			wrapped = append(wrapped, string(runes[:n]))
+			runes = runes[n:]
+		}
+	}
+	return wrapped
+}
+
+func addPreviewBuiltins(prefix string, l func(*Editor) *listing) {
+	add := func(name string, f func(*Editor)) {
+		builtins = append(builtins, Builtin{prefix + name, f})
+	}
+	add("preview-up", func(ed *Editor) { l(ed).previewScroll(-1) })
+	add("preview-down", func(ed *Editor) { l(ed).previewScroll(1) })
+	add("preview-page-up", func(ed *Editor) { lst := l(ed); lst.previewScroll(-lst.previewPageSize()) })
+	add("preview-page-down", func(ed *Editor) { lst := l(ed); lst.previewScroll(lst.previewPageSize()) })
+	add("preview-toggle", func(ed *Editor) { l(ed).previewToggle() })
+}
+
+// previewPageSize reports how many lines a preview-page-up/down should
+// scroll by, based on the pane's last rendered height.
+func (l *listing) previewPageSize() int {
+	if l.previewHeight > 0 {
+		return l.previewHeight
+	}
+	return 10
+}